@@ -0,0 +1,338 @@
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/TheGroobi/go-grab/pkg/validators"
+	"github.com/TheGroobi/go-grab/pkg/workers"
+	"github.com/spf13/cobra"
+)
+
+var (
+	bundleCmd = &cobra.Command{
+		Use:   "bundle [URLs...]",
+		Short: "Download many URLs concurrently into a single tar, tar.gz or zip archive",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 && FromFile == "" {
+				return errors.New("Requires at least 1 URL, or --from-file")
+			}
+
+			return nil
+		},
+		Run: downloadBundle,
+	}
+
+	FromFile  string
+	BundleOut string
+	SpoolMB   int
+)
+
+func downloadBundle(cmd *cobra.Command, args []string) {
+	t := time.Now()
+
+	urls, err := collectBundleURLs(args, FromFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	w, closeOut, err := openBundleOutput(BundleOut)
+	if err != nil {
+		log.Fatal("Error: failed to open output ", err)
+	}
+	defer closeOut()
+
+	arc, err := newArchiver(w, BundleOut)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	spoolDir, err := os.MkdirTemp("", "go-grab-bundle-*")
+	if err != nil {
+		log.Fatal("Error: failed to create a spool directory", err)
+	}
+	defer os.RemoveAll(spoolDir)
+
+	spoolBytes := int64(SpoolMB) * (1 << 20)
+
+	var archiveMu sync.Mutex
+
+	tasks := make([]workers.Task, len(urls))
+	for i, u := range urls {
+		u := u
+		tasks[i] = workers.Task{ID: i + 1, ExecFunc: func() {
+			addURLToBundle(u, spoolBytes, spoolDir, arc, &archiveMu)
+		}}
+	}
+
+	wp := workers.WorkerPool{
+		Tasks:       tasks,
+		Concurrency: int(math.Min(float64(len(tasks)), float64(runtime.NumCPU()))),
+	}
+
+	wp.Run()
+
+	// Close explicitly, before printing anything, so the zip/tar trailer
+	// is flushed to w before any status chatter can land after it on the
+	// same stream (notably stdout, for "-o -").
+	if err := arc.Close(); err != nil {
+		log.Fatal("Error: failed to finalize archive ", err)
+	}
+
+	fmt.Fprintln(os.Stderr, "Bundle written to ", strings.ReplaceAll(BundleOut, "\\", "/"))
+	fmt.Fprintf(os.Stderr, "Bundling took %v\n", time.Since(t))
+}
+
+func addURLToBundle(u string, spoolBytes int64, spoolDir string, arc archiver, archiveMu *sync.Mutex) {
+	fi, err := getFileInfo(u)
+	if err != nil && err != ErrRangeNotSupported {
+		log.Printf("Failed to download %s: %v\n", u, err)
+		return
+	}
+
+	name := bundleEntryName(fi, u)
+
+	reader, size, cleanup, err := spoolDownload(fi, u, spoolBytes, spoolDir)
+	if err != nil {
+		log.Printf("Failed to download %s: %v\n", u, err)
+		return
+	}
+	defer cleanup()
+	defer reader.Close()
+
+	// archive/zip and archive/tar are not concurrent-safe, so every entry
+	// is written under one mutex even though downloads race ahead of it.
+	archiveMu.Lock()
+	defer archiveMu.Unlock()
+
+	if err := arc.WriteEntry(name, size, reader); err != nil {
+		log.Printf("Failed to add %s to the archive: %v\n", u, err)
+	}
+}
+
+// spoolDownload downloads url's body (chunked when the server supports
+// ranges, reusing FileInfo's usual path) into either an in-memory buffer
+// bounded by spoolBytes or a temp file under spoolDir, and returns a reader
+// over the result along with a cleanup func that releases it.
+func spoolDownload(fi *FileInfo, u string, spoolBytes int64, spoolDir string) (io.ReadCloser, int64, func(), error) {
+	var cleanup func()
+
+	if fi.Size > 0 && fi.Size <= spoolBytes {
+		m := &memSpool{buf: make([]byte, fi.Size)}
+		fi.writer = m
+		fi.closer = m
+		cleanup = func() {}
+	} else {
+		tmp, err := os.CreateTemp(spoolDir, "spool-*")
+		if err != nil {
+			return nil, 0, nil, err
+		}
+
+		fi.writer = tmp
+		fi.closer = tmp
+		cleanup = func() { os.Remove(tmp.Name()) }
+	}
+
+	if fi.Size <= 0 {
+		maxRetries := 3
+		var bytesWritten int64
+		var err error
+		for r := 0; r < maxRetries; r++ {
+			bytesWritten, err = fi.StreamBufInChunks(u)
+			if err == nil && bytesWritten != 0 {
+				break
+			}
+
+			log.Printf("Failed to write bytes %d (attempt %d/%d): %v\n", bytesWritten, r+1, maxRetries, err)
+			time.Sleep(2 * time.Second)
+		}
+		fi.Size = bytesWritten
+	} else if fi.AcceptsRanges {
+		fi.ChunkSize = float64(ChunkSizeMB) * (1 << 20)
+		fi.DownloadInChunks(u)
+	} else {
+		// Content-Length is known but the server didn't advertise Range
+		// support; neither branch above applies, so fall back to a plain
+		// stream the same way the primary download path does.
+		fi.streamWithRetries(u)
+	}
+
+	fi.closer.Close()
+
+	if m, ok := fi.writer.(*memSpool); ok {
+		return io.NopCloser(m.reader()), fi.Size, cleanup, nil
+	}
+
+	file := fi.writer.(*os.File)
+	r, err := os.Open(file.Name())
+	if err != nil {
+		cleanup()
+		return nil, 0, nil, err
+	}
+
+	return r, fi.Size, cleanup, nil
+}
+
+// memSpool is an in-memory io.WriterAt sized to the full download up front;
+// disjoint WriteAt calls from concurrent chunk workers never overlap, so no
+// locking is needed between them.
+type memSpool struct {
+	buf []byte
+}
+
+func (m *memSpool) WriteAt(p []byte, off int64) (int, error) {
+	if off < 0 || int(off)+len(p) > len(m.buf) {
+		return 0, fmt.Errorf("Error: spool write out of bounds")
+	}
+
+	copy(m.buf[off:], p)
+
+	return len(p), nil
+}
+
+func (m *memSpool) Close() error { return nil }
+
+func (m *memSpool) reader() io.Reader { return strings.NewReader(string(m.buf)) }
+
+func bundleEntryName(fi *FileInfo, rawURL string) string {
+	if fi != nil && fi.Name != "" && fi.Name != "download" {
+		return fi.fileName()
+	}
+
+	name := "download"
+	if u, err := url.Parse(rawURL); err == nil {
+		if base := path.Base(u.Path); base != "" && base != "/" && base != "." {
+			name = base
+		}
+	}
+
+	if fi != nil && fi.Ext != "" && filepath.Ext(name) == "" {
+		name = name + "." + fi.Ext
+	}
+
+	return name
+}
+
+func collectBundleURLs(args []string, fromFile string) ([]string, error) {
+	urls := append([]string(nil), args...)
+
+	if fromFile != "" {
+		file, err := os.Open(fromFile)
+		if err != nil {
+			return nil, fmt.Errorf("Error: couldn't open %s: %w", fromFile, err)
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			urls = append(urls, line)
+		}
+
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, u := range urls {
+		if !validators.URL(u) {
+			return nil, fmt.Errorf("Invalid URL. Please provide a valid link: %s", u)
+		}
+	}
+
+	return urls, nil
+}
+
+func openBundleOutput(out string) (io.Writer, func(), error) {
+	if out == "-" || out == "" {
+		return os.Stdout, func() {}, nil
+	}
+
+	file, err := os.Create(out)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return file, func() { file.Close() }, nil
+}
+
+// archiver writes downloaded files into a single archive. Writes must be
+// serialized by the caller since neither archive/zip nor archive/tar is
+// concurrent-safe.
+type archiver interface {
+	WriteEntry(name string, size int64, r io.Reader) error
+	Close() error
+}
+
+func newArchiver(w io.Writer, outPath string) (archiver, error) {
+	switch {
+	case strings.HasSuffix(outPath, ".tar.gz"), strings.HasSuffix(outPath, ".tgz"):
+		gz := gzip.NewWriter(w)
+		return &tarArchiver{tw: tar.NewWriter(gz), gz: gz}, nil
+	case strings.HasSuffix(outPath, ".tar"):
+		return &tarArchiver{tw: tar.NewWriter(w)}, nil
+	default:
+		// zip is also the default for "-o -" and unrecognized extensions.
+		return &zipArchiver{zw: zip.NewWriter(w)}, nil
+	}
+}
+
+type tarArchiver struct {
+	tw *tar.Writer
+	gz *gzip.Writer
+}
+
+func (a *tarArchiver) WriteEntry(name string, size int64, r io.Reader) error {
+	if err := a.tw.WriteHeader(&tar.Header{Name: name, Size: size, Mode: 0644}); err != nil {
+		return err
+	}
+
+	_, err := io.Copy(a.tw, r)
+	return err
+}
+
+func (a *tarArchiver) Close() error {
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+	if a.gz != nil {
+		return a.gz.Close()
+	}
+	return nil
+}
+
+type zipArchiver struct {
+	zw *zip.Writer
+}
+
+func (a *zipArchiver) WriteEntry(name string, size int64, r io.Reader) error {
+	w, err := a.zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func (a *zipArchiver) Close() error {
+	return a.zw.Close()
+}
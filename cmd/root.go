@@ -15,6 +15,9 @@ var (
 	AutoDetectChunks bool
 	OutputDir        string
 	Workers          int
+	Quiet            bool
+	ResumePath       string
+	Checksum         string
 )
 
 var rootCmd = &cobra.Command{
@@ -29,8 +32,27 @@ var rootCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(downloadCmd)
+	rootCmd.AddCommand(ytCmd)
+	rootCmd.AddCommand(bundleCmd)
 	downloadCmd.Flags().IntVarP(&ChunkSizeMB, "chunk-size", "c", ChunkSizeMB, "chunk size for download in mb")
-	downloadCmd.Flags().StringVarP(&OutputDir, "output", "o", files.GetDownloadsDir(), "directory where the file should be downloaded to, defaults to '$HOME/Downloads'")
+	downloadCmd.Flags().BoolVar(&AutoDetectChunks, "auto-chunks", false, "probe the server and pick chunk size and concurrency automatically; --chunk-size is used only if the probe fails")
+	downloadCmd.Flags().StringVarP(&OutputDir, "output", "o", files.GetDownloadsDir(), "directory where the file should be downloaded to, defaults to '$HOME/Downloads'; an s3://bucket/prefix URL streams straight into S3 instead")
+	downloadCmd.Flags().BoolVarP(&Quiet, "quiet", "q", false, "disable progress bars")
+	downloadCmd.Flags().StringVarP(&ResumePath, "resume", "r", "", "resume a previously interrupted download, given the target file path or its .meta.json sidecar")
+	downloadCmd.Flags().StringVar(&Checksum, "checksum", "", "verify the downloaded file against a checksum, e.g. sha256:HEX")
+
+	ytCmd.Flags().IntVarP(&Itag, "itag", "i", 0, "download the format with this itag")
+	ytCmd.Flags().StringVarP(&FormatSpec, "format", "f", "", "format selector; \"bestvideo+bestaudio\" is rejected because separate streams are not muxed, and any other value picks the best progressive format")
+	ytCmd.Flags().IntVar(&MaxHeight, "max-height", 0, "pick the best progressive format at or below this height")
+	ytCmd.Flags().BoolVarP(&ListFormats, "list-formats", "l", false, "print the available formats and exit")
+	ytCmd.Flags().StringVarP(&OutputDir, "output", "o", files.GetDownloadsDir(), "directory where the file should be downloaded to, defaults to '$HOME/Downloads'")
+	ytCmd.Flags().IntVarP(&ChunkSizeMB, "chunk-size", "c", ChunkSizeMB, "chunk size for download in mb")
+
+	bundleCmd.Flags().StringVar(&FromFile, "from-file", "", "read URLs to bundle from a file, one per line")
+	bundleCmd.Flags().StringVarP(&BundleOut, "output", "o", "out.zip", "archive to write the bundle to (.zip, .tar or .tar.gz), or '-' for stdout")
+	bundleCmd.Flags().IntVar(&SpoolMB, "spool-mb", 64, "buffer a downloaded file in memory when its size is known and at most this many MB; other files are spooled to a temp file")
+	bundleCmd.Flags().IntVarP(&ChunkSizeMB, "chunk-size", "c", ChunkSizeMB, "chunk size for download in mb")
+	bundleCmd.Flags().BoolVarP(&Quiet, "quiet", "q", false, "disable progress bars")
 }
 
 func Execute() {
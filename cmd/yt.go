@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/TheGroobi/go-grab/pkg/validators"
+	"github.com/TheGroobi/go-grab/pkg/ytdl"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ytCmd = &cobra.Command{
+		Use:   "yt [URL]",
+		Short: "Download a video from YouTube or a similar media site",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("Requires atleast 1 argument to be passed")
+			}
+
+			if !validators.URL(args[0]) {
+				return errors.New("Invalid URL. Please provide a valid link.")
+			}
+
+			return nil
+		},
+		Run: downloadVideo,
+	}
+
+	Itag        int
+	FormatSpec  string
+	MaxHeight   int
+	ListFormats bool
+)
+
+func downloadVideo(cmd *cobra.Command, args []string) {
+	url := args[0]
+
+	manifest, err := ytdl.ResolveCached(url)
+	if err != nil {
+		log.Fatal("Error: failed to resolve formats ", err)
+	}
+
+	if ListFormats {
+		printFormats(manifest)
+		return
+	}
+
+	f, err := selectFormat(manifest)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if f.URL == "" {
+		log.Fatal("Error: selected format requires signature decryption, which isn't supported; pick another --itag")
+	}
+
+	if f.ContentLength <= 0 {
+		f.ContentLength = probeContentLength(f.URL)
+	}
+
+	fi := &FileInfo{
+		Name:          sanitizeFilename(manifest.Title),
+		Ext:           f.Ext,
+		Size:          f.ContentLength,
+		AcceptsRanges: true,
+		Metadata: &FileMetadata{
+			URL:       f.URL,
+			TotalSize: f.ContentLength,
+		},
+	}
+
+	t := time.Now()
+
+	if fi.Size > 0 {
+		fi.ChunkSize = float64(ChunkSizeMB) * (1 << 20)
+	}
+
+	if err := fi.CreateFile(OutputDir); err != nil {
+		log.Fatal("Error: failed to create a file", err)
+	}
+	defer fi.File.Close()
+
+	if fi.Size <= 0 {
+		if _, err := fi.StreamBufInChunks(f.URL); err != nil {
+			log.Fatal("Error: failed to download video ", err)
+		}
+	} else {
+		fi.DownloadInChunks(f.URL)
+	}
+
+	if err := fi.backend.Finalize(fi.objectName); err != nil {
+		log.Fatal("Error: failed to finalize upload ", err)
+	}
+
+	fmt.Println("File downloaded Successfully and saved in ", strings.ReplaceAll(fi.GetFullPath(OutputDir), "\\", "/"))
+	fmt.Printf("Download took %v\n", time.Since(t))
+}
+
+func selectFormat(m *ytdl.Manifest) (ytdl.Format, error) {
+	switch {
+	case Itag > 0:
+		return m.ByItag(Itag)
+	case FormatSpec == "bestvideo+bestaudio":
+		return ytdl.Format{}, errors.New("Error: bestvideo+bestaudio requires muxing separate streams, which isn't supported; pick a progressive --itag instead")
+	default:
+		return m.BestProgressive(MaxHeight)
+	}
+}
+
+func printFormats(m *ytdl.Manifest) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "ITAG\tEXT\tRESOLUTION\tVCODEC\tACODEC\tBITRATE\tSIZE")
+	for _, f := range m.Formats {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%d\t%d\n", f.Itag, f.Ext, f.Resolution, f.VCodec, f.ACodec, f.Bitrate, f.ContentLength)
+	}
+}
+
+func probeContentLength(url string) int64 {
+	r, err := http.Head(url)
+	if err != nil {
+		return 0
+	}
+	defer r.Body.Close()
+
+	n, _ := strconv.ParseInt(r.Header.Get("Content-Length"), 10, 64)
+	return n
+}
+
+func sanitizeFilename(name string) string {
+	if name == "" {
+		return "video"
+	}
+
+	replacer := strings.NewReplacer("/", "-", "\\", "-", ":", "-")
+	return replacer.Replace(name)
+}
@@ -0,0 +1,102 @@
+package progress
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// MultiBar renders one line per chunk plus a trailing aggregate line
+// summing bytes written across every chunk. It is safe for concurrent use
+// by a workers.WorkerPool, where each worker owns a different chunk index.
+type MultiBar struct {
+	Quiet bool
+	Total int64
+
+	mu    sync.Mutex
+	bars  map[int]*chunkState
+	order []int
+	agg   *Bar
+	lines int
+	isTTY bool
+}
+
+type chunkState struct {
+	current int64
+	total   int64
+}
+
+// NewMultiBar returns a MultiBar covering total bytes across all chunks.
+func NewMultiBar(total int64) *MultiBar {
+	return &MultiBar{
+		Total: total,
+		bars:  make(map[int]*chunkState),
+		agg:   &Bar{Label: "total"},
+		isTTY: isTerminal(os.Stderr),
+	}
+}
+
+// ChunkDraw returns a DrawFunc bound to the given chunk index, suitable for
+// passing as progress.Reader.Draw.
+func (m *MultiBar) ChunkDraw(index int, total int64) DrawFunc {
+	m.mu.Lock()
+	if _, ok := m.bars[index]; !ok {
+		m.order = append(m.order, index)
+	}
+	m.bars[index] = &chunkState{total: total}
+	m.mu.Unlock()
+
+	return func(current, total int64) {
+		m.update(index, current, total)
+	}
+}
+
+func (m *MultiBar) update(index int, current, total int64) {
+	if m.Quiet {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.bars[index].current = current
+	if total > 0 {
+		m.bars[index].total = total
+	}
+
+	var sum int64
+	for _, c := range m.bars {
+		sum += c.current
+	}
+
+	if !m.isTTY {
+		fmt.Fprintf(os.Stderr, "\rtotal: %s", percent(sum, m.Total))
+		return
+	}
+
+	rate := m.agg.sample(sum)
+
+	// Move the cursor back to the top of the block before redrawing every
+	// chunk line plus the aggregate line. The whole redraw stays under the
+	// lock so concurrent chunk workers can't interleave their cursor moves.
+	if m.lines > 0 {
+		fmt.Fprintf(os.Stderr, "\x1b[%dA", m.lines)
+	}
+	m.lines = len(m.order) + 1
+
+	for _, idx := range m.order {
+		c := m.bars[idx]
+		fmt.Fprintf(os.Stderr, "\x1b[2Kchunk %d: %s\n", idx, percent(c.current, c.total))
+	}
+
+	fmt.Fprintf(os.Stderr, "\x1b[2K%s\n", m.agg.render(sum, m.Total, rate))
+}
+
+// Done finishes the aggregate bar.
+func (m *MultiBar) Done() {
+	if m.Quiet {
+		return
+	}
+
+	fmt.Fprintln(os.Stderr)
+}
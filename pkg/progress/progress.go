@@ -0,0 +1,205 @@
+// Package progress renders live progress bars for in-flight downloads.
+//
+// It follows the ioprogress pattern: a Reader wraps an underlying io.Reader
+// and reports (current, total) bytes on every Read, which a Bar turns into
+// a redrawn line on os.Stderr. Bars are driven independently so a single
+// download can show one bar, while concurrent chunk downloads can each get
+// their own bar plus a shared aggregate bar.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DrawFunc is called on every Read with the number of bytes read so far and
+// the total expected, in bytes. Total is 0 when the size is unknown.
+type DrawFunc func(current, total int64)
+
+// redrawInterval throttles redraws to roughly 10 Hz so fast reads don't
+// flood the terminal.
+const redrawInterval = 100 * time.Millisecond
+
+// Reader wraps an io.Reader and calls Draw on each Read, throttled to
+// redrawInterval. It is safe to use with a nil Draw, in which case it is a
+// no-op passthrough.
+type Reader struct {
+	io.Reader
+	Total   int64
+	Draw    DrawFunc
+	current int64
+	last    time.Time
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.current += int64(n)
+	}
+
+	if r.Draw != nil && (err == io.EOF || time.Since(r.last) >= redrawInterval) {
+		r.last = time.Now()
+		r.Draw(r.current, r.Total)
+	}
+
+	return n, err
+}
+
+// Bar renders a single progress line to os.Stderr, deriving speed from an
+// EWMA of bytes/sec and an ETA from the remaining bytes at that rate.
+type Bar struct {
+	Label string
+	Quiet bool
+
+	mu       sync.Mutex
+	rate     float64
+	lastTime time.Time
+	lastSize int64
+	isTTY    bool
+}
+
+// NewBar returns a Bar labelled for a single download. isTTY is computed
+// once so every redraw uses a consistent rendering mode.
+func NewBar(label string) *Bar {
+	return &Bar{Label: label, isTTY: isTerminal(os.Stderr)}
+}
+
+// ewmaAlpha weights the most recent sample; higher values track bursts more
+// closely at the cost of a jumpier ETA.
+const ewmaAlpha = 0.3
+
+func (b *Bar) sample(current int64) (bytesPerSec float64) {
+	now := time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.lastTime.IsZero() {
+		b.lastTime = now
+		b.lastSize = current
+		return 0
+	}
+
+	elapsed := now.Sub(b.lastTime).Seconds()
+	if elapsed <= 0 {
+		return b.rate
+	}
+
+	instant := float64(current-b.lastSize) / elapsed
+	if b.rate == 0 {
+		b.rate = instant
+	} else {
+		b.rate = ewmaAlpha*instant + (1-ewmaAlpha)*b.rate
+	}
+
+	b.lastTime = now
+	b.lastSize = current
+
+	return b.rate
+}
+
+// Draw renders the current progress. When detached (Quiet) it does nothing.
+func (b *Bar) Draw(current, total int64) {
+	if b.Quiet {
+		return
+	}
+
+	rate := b.sample(current)
+
+	if !b.isTTY {
+		fmt.Fprintf(os.Stderr, "\r%s: %s", b.Label, percent(current, total))
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "\r%s", b.render(current, total, rate))
+}
+
+// Done finishes the bar, printing a trailing newline so subsequent output
+// doesn't overwrite the final line.
+func (b *Bar) Done() {
+	if b.Quiet {
+		return
+	}
+
+	fmt.Fprintln(os.Stderr)
+}
+
+func (b *Bar) render(current, total int64, bytesPerSec float64) string {
+	const width = 30
+
+	var bar string
+	if total > 0 {
+		filled := int(float64(width) * float64(current) / float64(total))
+		if filled > width {
+			filled = width
+		}
+		bar = "[" + strings.Repeat("=", filled) + strings.Repeat(" ", width-filled) + "]"
+	} else {
+		bar = "[" + strings.Repeat("?", width) + "]"
+	}
+
+	return fmt.Sprintf("%s %s %s %s %s", b.Label, bar, percent(current, total), speed(bytesPerSec), eta(current, total, bytesPerSec))
+}
+
+func percent(current, total int64) string {
+	if total <= 0 {
+		return fmt.Sprintf("%s", humanBytes(current))
+	}
+
+	return fmt.Sprintf("%5.1f%%", float64(current)/float64(total)*100)
+}
+
+func speed(bytesPerSec float64) string {
+	if bytesPerSec <= 0 {
+		return "-- /s"
+	}
+
+	return humanBytes(int64(bytesPerSec)) + "/s"
+}
+
+func eta(current, total int64, bytesPerSec float64) string {
+	if total <= 0 || bytesPerSec <= 0 {
+		return "ETA --:--"
+	}
+
+	remaining := float64(total-current) / bytesPerSec
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	d := time.Duration(remaining) * time.Second
+
+	return fmt.Sprintf("ETA %02d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+func humanBytes(n int64) string {
+	const unit = 1024
+
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// isTerminal reports whether f looks like a TTY. It relies only on the
+// stdlib: a character device is the common signal used to fall back to a
+// single-line percentage when output is redirected to a file or pipe.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,46 @@
+// Package localfs is the default storage.Backend, matching go-grab's
+// original behavior of writing straight to a local *os.File via WriteAt.
+package localfs
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Backend writes to the local filesystem, rooted at BaseDir.
+type Backend struct {
+	BaseDir string
+}
+
+// New returns a local filesystem backend rooted at baseDir.
+func New(baseDir string) *Backend {
+	return &Backend{BaseDir: baseDir}
+}
+
+// WriterAt creates BaseDir/name (and any missing parent directories) and
+// returns the open file, which satisfies both io.WriterAt and io.Closer.
+// chunkSize is unused: a plain file's WriteAt already addresses by real
+// byte offset, so it needs no notion of chunk boundaries.
+func (b *Backend) WriterAt(name string, size, chunkSize int64) (io.WriterAt, io.Closer, error) {
+	path := filepath.Join(b.BaseDir, name)
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return file, file, nil
+}
+
+// Finalize is a no-op; the local file is already complete once its chunks
+// have been written and it has been closed.
+func (b *Backend) Finalize(name string) error {
+	return nil
+}
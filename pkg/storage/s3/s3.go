@@ -0,0 +1,249 @@
+// Package s3 is a storage.Backend that streams chunks straight into an S3
+// multipart upload, with each Chunk mapped to one upload part. It talks to
+// the S3 REST API directly over net/http and signs requests with AWS
+// Signature Version 4, so no AWS SDK dependency is required.
+package s3
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Backend uploads to a single S3 bucket, optionally under a key prefix.
+type Backend struct {
+	Bucket string
+	Prefix string
+	Region string
+
+	mu      sync.Mutex
+	uploads map[string]*multipartUpload
+}
+
+type multipartUpload struct {
+	id       string
+	partSize int64
+
+	mu    sync.Mutex
+	parts []completedPart
+}
+
+type completedPart struct {
+	Number int
+	ETag   string
+}
+
+// New returns a Backend for s3://bucket[/prefix] style output paths.
+func New(bucket, prefix string) *Backend {
+	return &Backend{
+		Bucket:  bucket,
+		Prefix:  prefix,
+		Region:  region(),
+		uploads: make(map[string]*multipartUpload),
+	}
+}
+
+// ParseURL splits a "s3://bucket/prefix" output path into its bucket and
+// key prefix.
+func ParseURL(raw string) (bucket, prefix string, ok bool) {
+	const scheme = "s3://"
+	if !strings.HasPrefix(raw, scheme) {
+		return "", "", false
+	}
+
+	rest := strings.TrimPrefix(raw, scheme)
+	parts := strings.SplitN(rest, "/", 2)
+	bucket = parts[0]
+	if len(parts) == 2 {
+		prefix = parts[1]
+	}
+
+	return bucket, prefix, bucket != ""
+}
+
+func region() string {
+	if r := os.Getenv("AWS_REGION"); r != "" {
+		return r
+	}
+	if r := os.Getenv("AWS_DEFAULT_REGION"); r != "" {
+		return r
+	}
+	return "us-east-1"
+}
+
+func credsFromEnv() credentials {
+	return credentials{
+		accessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+func (b *Backend) key(name string) string {
+	if b.Prefix == "" {
+		return name
+	}
+	return strings.TrimSuffix(b.Prefix, "/") + "/" + strings.TrimPrefix(name, "/")
+}
+
+func (b *Backend) endpoint() string {
+	if b.Region == "us-east-1" {
+		return fmt.Sprintf("https://%s.s3.amazonaws.com", b.Bucket)
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com", b.Bucket, b.Region)
+}
+
+func (b *Backend) do(method, path, query string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, b.endpoint()+"/"+path+query, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	signRequest(req, body, b.Region, credsFromEnv())
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("Error: S3 request failed with %d: %s", resp.StatusCode, msg)
+	}
+
+	return resp, nil
+}
+
+// WriterAt starts a multipart upload for name and returns a writer whose
+// WriteAt offsets are mapped to S3 part numbers using chunkSize, which must
+// match the fixed chunk size the caller will actually write with. A
+// chunkSize of 0 means the caller doesn't know it up front (e.g. a
+// streamed, unchunked download); the part size is then learned from
+// whichever write happens to arrive first, as there's only ever one writer
+// for that path.
+func (b *Backend) WriterAt(name string, size, chunkSize int64) (io.WriterAt, io.Closer, error) {
+	key := b.key(name)
+
+	resp, err := b.do("POST", key, "?uploads=", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	var init struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&init); err != nil {
+		return nil, nil, fmt.Errorf("Error: couldn't parse CreateMultipartUpload response: %w", err)
+	}
+
+	u := &multipartUpload{id: init.UploadID, partSize: chunkSize}
+
+	b.mu.Lock()
+	b.uploads[key] = u
+	b.mu.Unlock()
+
+	w := &partWriter{backend: b, key: key, upload: u}
+
+	return w, w, nil
+}
+
+// Finalize completes the multipart upload started for name.
+func (b *Backend) Finalize(name string) error {
+	key := b.key(name)
+
+	b.mu.Lock()
+	u, ok := b.uploads[key]
+	delete(b.uploads, key)
+	b.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("Error: no in-progress upload for %s", key)
+	}
+
+	u.mu.Lock()
+	parts := append([]completedPart(nil), u.parts...)
+	u.mu.Unlock()
+
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+
+	var body bytes.Buffer
+	body.WriteString(`<CompleteMultipartUpload>`)
+	for _, p := range parts {
+		fmt.Fprintf(&body, `<Part><PartNumber>%d</PartNumber><ETag>%s</ETag></Part>`, p.Number, p.ETag)
+	}
+	body.WriteString(`</CompleteMultipartUpload>`)
+
+	resp, err := b.do("POST", key, "?uploadId="+u.id, body.Bytes())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// partWriter implements io.WriterAt and io.Closer over one multipart
+// upload, translating byte offsets into S3 part numbers.
+type partWriter struct {
+	backend *Backend
+	key     string
+	upload  *multipartUpload
+}
+
+func (w *partWriter) WriteAt(p []byte, off int64) (int, error) {
+	w.upload.mu.Lock()
+	if w.upload.partSize == 0 {
+		// Only reached for the unchunked streaming path, which has a
+		// single writer and so can't race: chunked downloads always pass
+		// an explicit chunkSize to WriterAt instead of relying on this.
+		w.upload.partSize = int64(len(p))
+	}
+	partSize := w.upload.partSize
+	w.upload.mu.Unlock()
+
+	if partSize == 0 {
+		return 0, fmt.Errorf("Error: can't determine S3 part size from an empty write")
+	}
+
+	partNumber := int(off/partSize) + 1
+
+	etag, err := w.uploadPart(partNumber, p)
+	if err != nil {
+		return 0, err
+	}
+
+	w.upload.mu.Lock()
+	w.upload.parts = append(w.upload.parts, completedPart{Number: partNumber, ETag: etag})
+	w.upload.mu.Unlock()
+
+	return len(p), nil
+}
+
+func (w *partWriter) uploadPart(partNumber int, data []byte) (string, error) {
+	query := fmt.Sprintf("?partNumber=%d&uploadId=%s", partNumber, w.upload.id)
+
+	resp, err := w.backend.do("PUT", w.key, query, data)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return "", fmt.Errorf("Error: S3 UploadPart response had no ETag")
+	}
+
+	return etag, nil
+}
+
+func (w *partWriter) Close() error {
+	return nil
+}
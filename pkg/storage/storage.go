@@ -0,0 +1,37 @@
+// Package storage abstracts the destination a download is written to,
+// generalizing the *os.File plus WriteAt pattern FileInfo used to be
+// hardwired to so chunks can stream straight into remote storage instead
+// of always going through a local file.
+package storage
+
+import "io"
+
+// Backend is a pluggable output destination. WriterAt opens name for
+// random-access writes; size is the total number of bytes that will
+// eventually be written, when known (0 otherwise), so a backend that needs
+// to pre-allocate or size its upload can use it. chunkSize is the size of
+// each chunk the caller will write with WriteAt (0 when the download isn't
+// chunked), so a backend that maps offsets to fixed-size units (e.g. S3
+// multipart part numbers) can derive that mapping directly instead of
+// inferring it from whichever write happens to arrive first. Finalize is
+// called once every chunk has been written, to complete anything WriterAt
+// left open (e.g. an S3 multipart upload); it is a no-op for backends that
+// don't need one.
+type Backend interface {
+	WriterAt(name string, size, chunkSize int64) (io.WriterAt, io.Closer, error)
+	Finalize(name string) error
+}
+
+// SequentialWriter adapts an io.WriterAt into a plain io.Writer for
+// single-stream writes (e.g. an unchunked download) against a backend built
+// around random-access chunk writes.
+type SequentialWriter struct {
+	W      io.WriterAt
+	offset int64
+}
+
+func (s *SequentialWriter) Write(p []byte) (int, error) {
+	n, err := s.W.WriteAt(p, s.offset)
+	s.offset += int64(n)
+	return n, err
+}
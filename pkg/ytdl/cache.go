@@ -0,0 +1,69 @@
+package ytdl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheTTL bounds how long a cached manifest is trusted before Resolve is
+// called again, so a long-stalled retry doesn't hand back an expired URL.
+const cacheTTL = 10 * time.Minute
+
+func cachePath(videoURL string) string {
+	sum := sha256.Sum256([]byte(videoURL))
+	return filepath.Join(os.TempDir(), "go-grab-yt-"+hex.EncodeToString(sum[:8])+".json")
+}
+
+// LoadCached returns a previously cached manifest for videoURL, if one
+// exists and is younger than cacheTTL.
+func LoadCached(videoURL string) (*Manifest, bool) {
+	p := cachePath(videoURL)
+
+	info, err := os.Stat(p)
+	if err != nil || time.Since(info.ModTime()) > cacheTTL {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, false
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, false
+	}
+
+	return &m, true
+}
+
+// Cache writes m to the on-disk cache for videoURL so retries within
+// cacheTTL don't re-resolve the manifest.
+func Cache(videoURL string, m *Manifest) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(cachePath(videoURL), data, 0644)
+}
+
+// ResolveCached is Resolve with an on-disk manifest cache in front of it.
+func ResolveCached(videoURL string) (*Manifest, error) {
+	if m, ok := LoadCached(videoURL); ok {
+		return m, nil
+	}
+
+	m, err := Resolve(videoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = Cache(videoURL, m)
+
+	return m, nil
+}
@@ -0,0 +1,40 @@
+package ytdl
+
+import "fmt"
+
+// ByItag returns the format with the given itag.
+func (m *Manifest) ByItag(itag int) (Format, error) {
+	for _, f := range m.Formats {
+		if f.Itag == itag {
+			return f, nil
+		}
+	}
+
+	return Format{}, fmt.Errorf("Error: no format with itag %d", itag)
+}
+
+// BestProgressive returns the highest-resolution progressive (single-file,
+// video+audio) format at or below maxHeight. maxHeight <= 0 means no cap.
+func (m *Manifest) BestProgressive(maxHeight int) (Format, error) {
+	var best Format
+	found := false
+
+	for _, f := range m.Formats {
+		if !f.Progressive() {
+			continue
+		}
+		if maxHeight > 0 && f.Height > maxHeight {
+			continue
+		}
+		if !found || f.Height > best.Height {
+			best = f
+			found = true
+		}
+	}
+
+	if !found {
+		return Format{}, fmt.Errorf("Error: no progressive (video+audio) format available within the requested constraints")
+	}
+
+	return best, nil
+}
@@ -0,0 +1,190 @@
+// Package ytdl resolves a YouTube watch page into its available media
+// formats, ytdl-style: fetch the page, pull the embedded player response
+// JSON out of it, and turn that into a flat list of downloadable formats.
+//
+// It only resolves formats whose media URL is already present in the
+// player response. YouTube increasingly serves "signatureCipher" formats
+// whose URL must be descrambled by running the page's own JS cipher
+// function; decoding that is out of scope here, so those formats are
+// reported back with an empty URL. ByItag and BestProgressive can still
+// return such a format, and the yt command exits with an error for it.
+package ytdl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Format describes a single downloadable stream.
+type Format struct {
+	Itag          int    `json:"itag"`
+	Ext           string `json:"ext"`
+	Resolution    string `json:"resolution"`
+	Height        int    `json:"height"`
+	VCodec        string `json:"vcodec"`
+	ACodec        string `json:"acodec"`
+	Bitrate       int    `json:"bitrate"`
+	ContentLength int64  `json:"content_length"`
+	URL           string `json:"url"`
+}
+
+// Progressive reports whether the format carries both video and audio, and
+// so can be downloaded as a single file without muxing.
+func (f Format) Progressive() bool {
+	return f.VCodec != "" && f.ACodec != ""
+}
+
+// Manifest is the resolved set of formats for one video.
+type Manifest struct {
+	Title   string   `json:"title"`
+	Formats []Format `json:"formats"`
+}
+
+var playerResponseRe = regexp.MustCompile(`ytInitialPlayerResponse\s*=\s*(\{.+?\});`)
+
+// Resolve fetches videoURL's watch page and extracts its available formats.
+func Resolve(videoURL string) (*Manifest, error) {
+	req, err := http.NewRequest("GET", videoURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error: couldn't create a request to resolve formats")
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Error: failed to fetch video page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("Error: video page responded with: |%d|", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	match := playerResponseRe.FindSubmatch(body)
+	if match == nil {
+		return nil, fmt.Errorf("Error: couldn't find player response on the video page")
+	}
+
+	var pr playerResponse
+	if err := json.Unmarshal(match[1], &pr); err != nil {
+		return nil, fmt.Errorf("Error: couldn't parse player response: %w", err)
+	}
+
+	m := &Manifest{Title: pr.VideoDetails.Title}
+
+	for _, raw := range append(pr.StreamingData.Formats, pr.StreamingData.AdaptiveFormats...) {
+		m.Formats = append(m.Formats, raw.toFormat())
+	}
+
+	if len(m.Formats) == 0 {
+		return nil, fmt.Errorf("Error: no formats found for %s", videoURL)
+	}
+
+	return m, nil
+}
+
+type playerResponse struct {
+	VideoDetails struct {
+		Title string `json:"title"`
+	} `json:"videoDetails"`
+	StreamingData struct {
+		Formats         []rawFormat `json:"formats"`
+		AdaptiveFormats []rawFormat `json:"adaptiveFormats"`
+	} `json:"streamingData"`
+}
+
+type rawFormat struct {
+	Itag            int    `json:"itag"`
+	MimeType        string `json:"mimeType"`
+	Bitrate         int    `json:"bitrate"`
+	Width           int    `json:"width"`
+	Height          int    `json:"height"`
+	ContentLength   string `json:"contentLength"`
+	Quality         string `json:"quality"`
+	QualityLabel    string `json:"qualityLabel"`
+	URL             string `json:"url"`
+	SignatureCipher string `json:"signatureCipher"`
+	Cipher          string `json:"cipher"`
+}
+
+func (r rawFormat) toFormat() Format {
+	ext, vcodec, acodec := parseMimeType(r.MimeType)
+
+	length, _ := strconv.ParseInt(r.ContentLength, 10, 64)
+
+	resolution := r.QualityLabel
+	if resolution == "" && r.Width > 0 && r.Height > 0 {
+		resolution = fmt.Sprintf("%dx%d", r.Width, r.Height)
+	}
+
+	return Format{
+		Itag:          r.Itag,
+		Ext:           ext,
+		Resolution:    resolution,
+		Height:        r.Height,
+		VCodec:        vcodec,
+		ACodec:        acodec,
+		Bitrate:       r.Bitrate,
+		ContentLength: length,
+		// r.URL is empty for signatureCipher/cipher formats, which require
+		// decoding a per-page JS function we intentionally don't execute.
+		URL: r.URL,
+	}
+}
+
+// parseMimeType turns `video/mp4; codecs="avc1.640028, mp4a.40.2"` into its
+// extension and the codec(s) it carries, splitting combined audio/video
+// codec lists into the video and audio slot by convention (first = video).
+func parseMimeType(mime string) (ext, vcodec, acodec string) {
+	parts := strings.SplitN(mime, ";", 2)
+	typ := strings.TrimSpace(parts[0])
+
+	slashIdx := strings.Index(typ, "/")
+	if slashIdx == -1 {
+		return "", "", ""
+	}
+
+	major, minor := typ[:slashIdx], typ[slashIdx+1:]
+	ext = minor
+
+	if len(parts) != 2 {
+		return ext, "", ""
+	}
+
+	codecsIdx := strings.Index(parts[1], "codecs=")
+	if codecsIdx == -1 {
+		return ext, "", ""
+	}
+
+	codecs := strings.Trim(strings.TrimSpace(parts[1][codecsIdx+len("codecs="):]), `"`)
+	list := strings.Split(codecs, ",")
+	for i := range list {
+		list[i] = strings.TrimSpace(list[i])
+	}
+
+	switch major {
+	case "video":
+		if len(list) > 0 {
+			vcodec = list[0]
+		}
+		if len(list) > 1 {
+			acodec = list[1]
+		}
+	case "audio":
+		if len(list) > 0 {
+			acodec = list[0]
+		}
+	}
+
+	return ext, vcodec, acodec
+}